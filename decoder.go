@@ -5,6 +5,7 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"io"
 	"math"
 	"reflect"
 	"strings"
@@ -63,13 +64,22 @@ var TypeSize = struct {
 
 // Decoder implements the EOS unpacking, similar to FC_BUFFER
 type Decoder struct {
-	data []byte
-	pos  int
+	reader decReader
 }
 
 func NewDecoder(data []byte) *Decoder {
 	return &Decoder{
-		data: data,
+		reader: newBytesDecReader(data),
+	}
+}
+
+// NewDecoderFromReader returns a Decoder that pulls its bytes lazily from r
+// instead of requiring the whole payload to be buffered in memory up front,
+// so arbitrarily large EOSIO-style blocks or ABI blobs can be streamed off
+// of something like a net.Conn.
+func NewDecoderFromReader(r io.Reader) *Decoder {
+	return &Decoder{
+		reader: newIODecReader(r),
 	}
 }
 
@@ -78,11 +88,25 @@ func (d *Decoder) Decode(v interface{}) (err error) {
 }
 
 func (d *Decoder) decodeWithOption(v interface{}, option *Option) (err error) {
+	// `optional` still needs its presence byte read before we know whether
+	// there's a value to decode at all, so the fastpath only kicks in for
+	// plain fields; it short-circuits the reflection-based walk below for
+	// the concrete types it knows about.
 	rv := reflect.ValueOf(v)
 	//if rv.Kind() != reflect.Ptr || rv.IsNil() {
 	//	return &InvalidDecoderError{reflect.TypeOf(v)}
 	//}
 
+	if option == nil || !option.isOptional() {
+		if handled, ferr := d.fastpath(v, option); handled {
+			return ferr
+		}
+
+		if handled, ferr := d.fastpathByteArray(rv); handled {
+			return ferr
+		}
+	}
+
 	// We decode rv not rv.Elem because the Unmarshaler interface
 	// test must be applied at the top level of the value.
 	err = d.value(rv, option)
@@ -288,6 +312,36 @@ func (d *Decoder) value(rv reflect.Value, option *Option) (err error) {
 			}
 		}
 
+	case reflect.Map:
+		var l uint64
+		l, err = d.ReadUvarint64()
+		if err != nil {
+			return
+		}
+
+		if traceEnabled {
+			zlog.Debug("reading map", zap.Uint64("len", l), typeField("type", rv))
+		}
+
+		keyType := rvType.Key()
+		valueType := rvType.Elem()
+
+		out := reflect.MakeMapWithSize(rvType, int(l))
+		for i := 0; i < int(l); i++ {
+			key := reflect.New(keyType)
+			if err = d.decodeWithOption(key.Interface(), nil); err != nil {
+				return
+			}
+
+			value := reflect.New(valueType)
+			if err = d.decodeWithOption(value.Interface(), nil); err != nil {
+				return
+			}
+
+			out.SetMapIndex(key.Elem(), value.Elem())
+		}
+		rv.Set(out)
+
 	case reflect.Struct:
 
 		err = d.decodeStruct(rvType, rv)
@@ -330,12 +384,26 @@ func (d *Decoder) decodeStruct(rt reflect.Type, rv reflect.Value) (err error) {
 			//        But at the same time, does it make sense otherwise? What would be the inference
 			//        rule in the case of extra bytes available? Continue decoding and revert if it's
 			//        not working? But how to detect valid errors?
-			if len(d.data[d.pos:]) <= 0 {
+			if d.reader.EOF() {
 				continue
 			}
 		}
 
 		if v := rv.Field(i); v.CanSet() && structField.Name != "_" {
+			if variantName, ok := variantTagName(structField.Tag); ok {
+				def, known := variantRegistry[variantName]
+				if !known {
+					return fmt.Errorf("decode: no variant definition registered for %q", variantName)
+				}
+
+				variant, e := d.DecodeVariant(def)
+				if e != nil {
+					return e
+				}
+				v.Set(reflect.ValueOf(variant))
+				continue
+			}
+
 			option := &Option{}
 
 			if s, ok := sizeOfMap[structField.Name]; ok {
@@ -397,28 +465,44 @@ func sizeof(t reflect.Type, v reflect.Value) int {
 
 var ErrVarIntBufferSize = errors.New("varint: invalid buffer size")
 
-func (d *Decoder) ReadUvarint64() (uint64, error) {
-	l, read := binary.Uvarint(d.data[d.pos:])
-	if read <= 0 {
-		return l, ErrVarIntBufferSize
-	}
-	if traceEnabled {
-		zlog.Debug("read uvarint64", zap.Uint64("val", l))
+// maxVarintBytes is the maximum number of bytes a 64-bit varuint can span;
+// anything longer than that means a corrupt or malicious stream.
+const maxVarintBytes = 10
+
+func (d *Decoder) ReadUvarint64() (out uint64, err error) {
+	var shift uint
+	for i := 0; i < maxVarintBytes; i++ {
+		b, e := d.reader.readn1()
+		if e != nil {
+			return out, e
+		}
+
+		out |= uint64(b&0x7f) << shift
+		if b < 0x80 {
+			if traceEnabled {
+				zlog.Debug("read uvarint64", zap.Uint64("val", out))
+			}
+			return out, nil
+		}
+		shift += 7
 	}
-	d.pos += read
-	return l, nil
+	return out, ErrVarIntBufferSize
 }
 
 func (d *Decoder) ReadVarint64() (out int64, err error) {
-	l, read := binary.Varint(d.data[d.pos:])
-	if read <= 0 {
-		return l, ErrVarIntBufferSize
+	ux, err := d.ReadUvarint64()
+	if err != nil {
+		return 0, err
+	}
+
+	out = int64(ux >> 1)
+	if ux&1 != 0 {
+		out = ^out
 	}
 	if traceEnabled {
-		zlog.Debug("read varint", zap.Int64("val", l))
+		zlog.Debug("read varint", zap.Int64("val", out))
 	}
-	d.pos += read
-	return l, nil
+	return out, nil
 }
 
 func (d *Decoder) ReadVarint32() (out int32, err error) {
@@ -477,12 +561,10 @@ func (d *Decoder) ReadByteArray() (out []byte, err error) {
 		return nil, err
 	}
 
-	if len(d.data) < d.pos+int(l) {
-		return nil, fmt.Errorf("byte array: varlen=%d, missing %d bytes", l, d.pos+int(l)-len(d.data))
+	out, err = d.reader.readn(int(l))
+	if err != nil {
+		return nil, fmt.Errorf("byte array: varlen=%d, %s", l, err)
 	}
-
-	out = d.data[d.pos : d.pos+int(l)]
-	d.pos += int(l)
 	if traceEnabled {
 		zlog.Debug("read byte array", zap.Stringer("hex", HexBytes(out)))
 	}
@@ -490,13 +572,12 @@ func (d *Decoder) ReadByteArray() (out []byte, err error) {
 }
 
 func (d *Decoder) ReadByte() (out byte, err error) {
-	if d.remaining() < TypeSize.Byte {
-		err = fmt.Errorf("required [1] byte, remaining [%d]", d.remaining())
+	out, err = d.reader.readn1()
+	if err != nil {
+		err = fmt.Errorf("required [1] byte, %s", err)
 		return
 	}
 
-	out = d.data[d.pos]
-	d.pos++
 	if traceEnabled {
 		zlog.Debug("read byte", zap.Uint8("byte", out), zap.String("hex", hex.EncodeToString([]byte{out})))
 	}
@@ -504,11 +585,6 @@ func (d *Decoder) ReadByte() (out byte, err error) {
 }
 
 func (d *Decoder) ReadBool() (out bool, err error) {
-	if d.remaining() < TypeSize.Bool {
-		err = fmt.Errorf("bool required [%d] byte, remaining [%d]", TypeSize.Bool, d.remaining())
-		return
-	}
-
 	b, err := d.ReadByte()
 
 	if err != nil {
@@ -537,13 +613,12 @@ func (d *Decoder) ReadInt8() (out int8, err error) {
 }
 
 func (d *Decoder) ReadUint16() (out uint16, err error) {
-	if d.remaining() < TypeSize.Uint16 {
-		err = fmt.Errorf("uint16 required [%d] bytes, remaining [%d]", TypeSize.Uint16, d.remaining())
-		return
+	data, err := d.reader.readn(TypeSize.Uint16)
+	if err != nil {
+		return 0, fmt.Errorf("uint16 required [%d] bytes, %s", TypeSize.Uint16, err)
 	}
 
-	out = binary.LittleEndian.Uint16(d.data[d.pos:])
-	d.pos += TypeSize.Uint16
+	out = binary.LittleEndian.Uint16(data)
 	if traceEnabled {
 		zlog.Debug("read uint16", zap.Uint16("val", out))
 	}
@@ -569,13 +644,12 @@ func (d *Decoder) ReadInt64() (out int64, err error) {
 }
 
 func (d *Decoder) ReadUint32() (out uint32, err error) {
-	if d.remaining() < TypeSize.Uint32 {
-		err = fmt.Errorf("uint32 required [%d] bytes, remaining [%d]", TypeSize.Uint32, d.remaining())
-		return
+	data, err := d.reader.readn(TypeSize.Uint32)
+	if err != nil {
+		return 0, fmt.Errorf("uint32 required [%d] bytes, %s", TypeSize.Uint32, err)
 	}
 
-	out = binary.LittleEndian.Uint32(d.data[d.pos:])
-	d.pos += TypeSize.Uint32
+	out = binary.LittleEndian.Uint32(data)
 	if traceEnabled {
 		zlog.Debug("read uint32", zap.Uint32("val", out))
 	}
@@ -592,14 +666,12 @@ func (d *Decoder) ReadInt32() (out int32, err error) {
 }
 
 func (d *Decoder) ReadUint64() (out uint64, err error) {
-	if d.remaining() < TypeSize.Uint64 {
-		err = fmt.Errorf("uint64 required [%d] bytes, remaining [%d]", TypeSize.Uint64, d.remaining())
-		return
+	data, err := d.reader.readn(TypeSize.Uint64)
+	if err != nil {
+		return 0, fmt.Errorf("uint64 required [%d] bytes, %s", TypeSize.Uint64, err)
 	}
 
-	data := d.data[d.pos : d.pos+TypeSize.Uint64]
 	out = binary.LittleEndian.Uint64(data)
-	d.pos += TypeSize.Uint64
 	if traceEnabled {
 		zlog.Debug("read uint64", zap.Uint64("val", out), zap.Stringer("hex", HexBytes(data)))
 	}
@@ -616,16 +688,14 @@ func (d *Decoder) ReadInt128() (out Int128, err error) {
 }
 
 func (d *Decoder) ReadUint128(typeName string) (out Uint128, err error) {
-	if d.remaining() < TypeSize.Uint128 {
-		err = fmt.Errorf("%s required [%d] bytes, remaining [%d]", typeName, TypeSize.Uint128, d.remaining())
-		return
+	data, err := d.reader.readn(TypeSize.Uint128)
+	if err != nil {
+		return out, fmt.Errorf("%s required [%d] bytes, %s", typeName, TypeSize.Uint128, err)
 	}
 
-	data := d.data[d.pos : d.pos+TypeSize.Uint128]
 	out.Lo = binary.LittleEndian.Uint64(data)
 	out.Hi = binary.LittleEndian.Uint64(data[8:])
 
-	d.pos += TypeSize.Uint128
 	if traceEnabled {
 		zlog.Debug("read uint128", zap.Stringer("hex", out), zap.Uint64("hi", out.Hi), zap.Uint64("lo", out.Lo))
 	}
@@ -633,14 +703,12 @@ func (d *Decoder) ReadUint128(typeName string) (out Uint128, err error) {
 }
 
 func (d *Decoder) ReadFloat32() (out float32, err error) {
-	if d.remaining() < TypeSize.Float32 {
-		err = fmt.Errorf("float32 required [%d] bytes, remaining [%d]", TypeSize.Float32, d.remaining())
-		return
+	data, err := d.reader.readn(TypeSize.Float32)
+	if err != nil {
+		return 0, fmt.Errorf("float32 required [%d] bytes, %s", TypeSize.Float32, err)
 	}
 
-	n := binary.LittleEndian.Uint32(d.data[d.pos:])
-	out = math.Float32frombits(n)
-	d.pos += TypeSize.Float32
+	out = math.Float32frombits(binary.LittleEndian.Uint32(data))
 	if traceEnabled {
 		zlog.Debug("read float32", zap.Float32("val", out))
 	}
@@ -648,14 +716,12 @@ func (d *Decoder) ReadFloat32() (out float32, err error) {
 }
 
 func (d *Decoder) ReadFloat64() (out float64, err error) {
-	if d.remaining() < TypeSize.Float64 {
-		err = fmt.Errorf("float64 required [%d] bytes, remaining [%d]", TypeSize.Float64, d.remaining())
-		return
+	data, err := d.reader.readn(TypeSize.Float64)
+	if err != nil {
+		return 0, fmt.Errorf("float64 required [%d] bytes, %s", TypeSize.Float64, err)
 	}
 
-	n := binary.LittleEndian.Uint64(d.data[d.pos:])
-	out = math.Float64frombits(n)
-	d.pos += TypeSize.Float64
+	out = math.Float64frombits(binary.LittleEndian.Uint64(data))
 	if traceEnabled {
 		zlog.Debug("read Float64", zap.Float64("val", float64(out)))
 	}
@@ -689,12 +755,18 @@ func (d *Decoder) ReadString() (out string, err error) {
 	return
 }
 
+// remaining returns how many bytes are left to read when the Decoder is
+// backed by an in-memory buffer, or -1 when it is streaming from an
+// io.Reader and the total length isn't known ahead of time.
 func (d *Decoder) remaining() int {
-	return len(d.data) - d.pos
+	if br, ok := d.reader.(*bytesDecReader); ok {
+		return len(br.data) - br.pos
+	}
+	return -1
 }
 
 func (d *Decoder) hasRemaining() bool {
-	return d.remaining() > 0
+	return !d.reader.EOF()
 }
 
 //func UnmarshalBinaryReader(reader io.Reader, v interface{}) (err error) {
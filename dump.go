@@ -0,0 +1,372 @@
+package bin
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Dump decodes data against v's type the same way Decode would, populating
+// v, and also returns an annotated trace of the walk - one
+// line per field, showing its byte offset, length, raw hex and decoded
+// value, alongside the Go field path that produced it (e.g.
+// `Transaction.Actions[3].Authorization[0].Actor`). It exists purely to
+// diagnose "off by one field" bugs without flipping on debug logging
+// globally, the same role `encoding/gob`'s debug.go plays for gob streams.
+func Dump(v interface{}, data []byte) (string, error) {
+	return NewDecoder(data).Trace(v)
+}
+
+// Trace is the Decoder-bound form of Dump: it walks v's type against
+// whatever bytes remain in the decoder and returns the annotated trace.
+func (d *Decoder) Trace(v interface{}) (string, error) {
+	t := &tracer{dec: d}
+
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		rv = rv.Elem()
+	}
+
+	err := t.walk(rv.Type().Name(), rv, false, noSizeOfSlice)
+	return t.buf.String(), err
+}
+
+// noSizeOfSlice marks a walk call whose slice length (if any) should be read
+// off the wire as a varuint prefix, rather than taken from a preceding
+// `bin:"sizeof=..."` field.
+const noSizeOfSlice = -1
+
+type tracer struct {
+	dec   *Decoder
+	buf   strings.Builder
+	depth int
+}
+
+func (t *tracer) line(path string, before int, desc string) {
+	after := t.dec.pos()
+	hex := ""
+	if raw := t.dec.rawSince(before); raw != nil {
+		hex = HexBytes(raw).String()
+	}
+
+	t.buf.WriteString(strings.Repeat("  ", t.depth))
+	fmt.Fprintf(&t.buf, "[%d:%d] %-48s %-24s %s\n", before, after, path, hex, desc)
+}
+
+func (t *tracer) marker(path string, text string) {
+	t.buf.WriteString(strings.Repeat("  ", t.depth))
+	fmt.Fprintf(&t.buf, "%-57s %s\n", path, text)
+}
+
+// walk decodes the value living at path using real reads off t.dec - so the
+// trace can never drift from what Decode actually does - and appends one or
+// more annotated lines describing what was read. sizeOfSlice is the length
+// to use for rv if it's a slice tagged `bin:"sizeof=..."` elsewhere in its
+// struct, or noSizeOfSlice to read the length as an inline varuint.
+func (t *tracer) walk(path string, rv reflect.Value, optional bool, sizeOfSlice int) (err error) {
+	if optional {
+		before := t.dec.pos()
+		isPresent, e := t.dec.ReadByte()
+		if e != nil {
+			return e
+		}
+		t.marker(path, fmt.Sprintf("optional presence=%d", isPresent))
+		if isPresent == 0 {
+			t.line(path, before, "<absent>")
+			return nil
+		}
+	}
+
+	if rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			rv.Set(reflect.New(rv.Type().Elem()))
+		}
+		return t.walk(path, rv.Elem(), false, sizeOfSlice)
+	}
+
+	if m, ok := rv.Addr().Interface().(UnmarshalerBinary); ok {
+		before := t.dec.pos()
+		if err = m.UnmarshalBinary(t.dec); err != nil {
+			return err
+		}
+		t.line(path, before, fmt.Sprintf("%s (UnmarshalBinary)", rv.Type()))
+		return nil
+	}
+
+	switch rv.Kind() {
+	case reflect.String:
+		before := t.dec.pos()
+		s, e := t.dec.ReadString()
+		if e != nil {
+			return e
+		}
+		t.line(path, before, fmt.Sprintf("%q", s))
+		rv.SetString(s)
+		return nil
+
+	case reflect.Bool:
+		before := t.dec.pos()
+		b, e := t.dec.ReadBool()
+		if e != nil {
+			return e
+		}
+		t.line(path, before, fmt.Sprintf("%v", b))
+		rv.SetBool(b)
+		return nil
+
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		before := t.dec.pos()
+		var n uint64
+		switch rv.Kind() {
+		case reflect.Uint8:
+			var v byte
+			v, err = t.dec.ReadByte()
+			n = uint64(v)
+		case reflect.Uint16:
+			var v uint16
+			v, err = t.dec.ReadUint16()
+			n = uint64(v)
+		case reflect.Uint32:
+			var v uint32
+			v, err = t.dec.ReadUint32()
+			n = uint64(v)
+		case reflect.Uint64:
+			n, err = t.dec.ReadUint64()
+		}
+		if err != nil {
+			return err
+		}
+		t.line(path, before, fmt.Sprintf("%d", n))
+		rv.SetUint(n)
+		return nil
+
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		before := t.dec.pos()
+		var n int64
+		switch rv.Kind() {
+		case reflect.Int8:
+			var v int8
+			v, err = t.dec.ReadInt8()
+			n = int64(v)
+		case reflect.Int16:
+			var v int16
+			v, err = t.dec.ReadInt16()
+			n = int64(v)
+		case reflect.Int32:
+			var v int32
+			v, err = t.dec.ReadInt32()
+			n = int64(v)
+		case reflect.Int64:
+			n, err = t.dec.ReadInt64()
+		}
+		if err != nil {
+			return err
+		}
+		t.line(path, before, fmt.Sprintf("%d", n))
+		rv.SetInt(n)
+		return nil
+
+	case reflect.Float32:
+		before := t.dec.pos()
+		f, e := t.dec.ReadFloat32()
+		if e != nil {
+			return e
+		}
+		t.line(path, before, fmt.Sprintf("%v", f))
+		rv.SetFloat(float64(f))
+		return nil
+
+	case reflect.Float64:
+		before := t.dec.pos()
+		f, e := t.dec.ReadFloat64()
+		if e != nil {
+			return e
+		}
+		t.line(path, before, fmt.Sprintf("%v", f))
+		rv.SetFloat(f)
+		return nil
+
+	case reflect.Slice:
+		if rv.Type().Elem().Kind() == reflect.Uint8 && sizeOfSlice == noSizeOfSlice {
+			before := t.dec.pos()
+			data, e := t.dec.ReadByteArray()
+			if e != nil {
+				return e
+			}
+			out := make([]byte, len(data))
+			copy(out, data)
+			t.line(path, before, fmt.Sprintf("%d bytes", len(out)))
+			rv.SetBytes(out)
+			return nil
+		}
+
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			before := t.dec.pos()
+			data, e := t.dec.reader.readn(sizeOfSlice)
+			if e != nil {
+				return e
+			}
+			out := make([]byte, len(data))
+			copy(out, data)
+			t.marker(path, fmt.Sprintf("sizeof length=%d", sizeOfSlice))
+			t.line(path, before, fmt.Sprintf("%d bytes", len(out)))
+			rv.SetBytes(out)
+			return nil
+		}
+
+		var l int
+		if sizeOfSlice != noSizeOfSlice {
+			l = sizeOfSlice
+			t.marker(path, fmt.Sprintf("sizeof length=%d", l))
+		} else {
+			length, e := t.dec.ReadUvarint64()
+			if e != nil {
+				return e
+			}
+			l = int(length)
+			t.marker(path, fmt.Sprintf("varuint length=%d", l))
+		}
+
+		rv.Set(reflect.MakeSlice(rv.Type(), l, l))
+		t.depth++
+		for i := 0; i < l; i++ {
+			if err = t.walk(fmt.Sprintf("%s[%d]", path, i), rv.Index(i), false, noSizeOfSlice); err != nil {
+				t.depth--
+				return err
+			}
+		}
+		t.depth--
+		return nil
+
+	case reflect.Array:
+		t.depth++
+		for i := 0; i < rv.Len(); i++ {
+			if err = t.walk(fmt.Sprintf("%s[%d]", path, i), rv.Index(i), false, noSizeOfSlice); err != nil {
+				t.depth--
+				return err
+			}
+		}
+		t.depth--
+		return nil
+
+	case reflect.Map:
+		l, e := t.dec.ReadUvarint64()
+		if e != nil {
+			return e
+		}
+		t.marker(path, fmt.Sprintf("varuint length=%d", l))
+
+		keyType := rv.Type().Key()
+		valueType := rv.Type().Elem()
+		out := reflect.MakeMapWithSize(rv.Type(), int(l))
+
+		t.depth++
+		for i := 0; i < int(l); i++ {
+			key := reflect.New(keyType).Elem()
+			if err = t.walk(fmt.Sprintf("%s.key[%d]", path, i), key, false, noSizeOfSlice); err != nil {
+				t.depth--
+				return err
+			}
+			value := reflect.New(valueType).Elem()
+			if err = t.walk(fmt.Sprintf("%s.value[%d]", path, i), value, false, noSizeOfSlice); err != nil {
+				t.depth--
+				return err
+			}
+			out.SetMapIndex(key, value)
+		}
+		t.depth--
+		rv.Set(out)
+		return nil
+
+	case reflect.Struct:
+		return t.walkStruct(path, rv)
+
+	default:
+		return fmt.Errorf("dump: unsupported type %q", rv.Type())
+	}
+}
+
+func (t *tracer) walkStruct(path string, rv reflect.Value) (err error) {
+	rt := rv.Type()
+	sizeOfMap := map[string]int{}
+	seenBinaryExtensionField := false
+
+	t.depth++
+	defer func() { t.depth-- }()
+
+	for i := 0; i < rv.NumField(); i++ {
+		structField := rt.Field(i)
+		fieldTag := parseFieldTag(structField.Tag)
+		if fieldTag.Skip {
+			continue
+		}
+
+		if !fieldTag.BinaryExtension && seenBinaryExtensionField {
+			panic(fmt.Sprintf("the `bin:\"binary_extension\"` tags must be packed together at the end of struct fields, problematic field %q", structField.Name))
+		}
+
+		fieldPath := path + "." + structField.Name
+
+		if fieldTag.BinaryExtension {
+			seenBinaryExtensionField = true
+			if t.dec.reader.EOF() {
+				t.marker(fieldPath, "<binary_extension tail, no more bytes: skipped>")
+				continue
+			}
+		}
+
+		if variantName, ok := variantTagName(structField.Tag); ok {
+			def, known := variantRegistry[variantName]
+			if !known {
+				return fmt.Errorf("dump: no variant definition registered for %q", variantName)
+			}
+
+			before := t.dec.pos()
+			variant, e := t.dec.DecodeVariant(def)
+			if e != nil {
+				return e
+			}
+			name, _ := def.Name(variant.TypeID)
+			t.line(fieldPath, before, fmt.Sprintf("variant %s (type_id=%d)", name, variant.TypeID))
+			rv.Field(i).Set(reflect.ValueOf(variant))
+			continue
+		}
+
+		if v := rv.Field(i); v.CanSet() && structField.Name != "_" {
+			sizeOfSlice := noSizeOfSlice
+			if s, ok := sizeOfMap[structField.Name]; ok {
+				sizeOfSlice = s
+			}
+
+			if err = t.walk(fieldPath, v, fieldTag.Optional, sizeOfSlice); err != nil {
+				return err
+			}
+
+			if fieldTag.Sizeof != "" {
+				sizeOfMap[fieldTag.Sizeof] = sizeof(structField.Type, v)
+			}
+		}
+	}
+	return nil
+}
+
+// rawSince returns the bytes consumed between `from` and the decoder's
+// current position, when the decoder is backed by an in-memory buffer; it
+// returns nil for a streaming decoder, which can't look backward.
+func (d *Decoder) rawSince(from int) []byte {
+	br, ok := d.reader.(*bytesDecReader)
+	if !ok {
+		return nil
+	}
+	return br.data[from:br.pos]
+}
+
+// pos returns the decoder's current byte offset when backed by an
+// in-memory buffer, or -1 for a streaming decoder.
+func (d *Decoder) pos() int {
+	br, ok := d.reader.(*bytesDecReader)
+	if !ok {
+		return -1
+	}
+	return br.pos
+}
@@ -0,0 +1,117 @@
+package bin
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+type dumpSizeofFixture struct {
+	Length  uint32 `bin:"sizeof=Payload"`
+	Payload []byte
+	Tail    uint8
+}
+
+func TestTrace_SizeofSliceDoesNotDriftSubsequentFields(t *testing.T) {
+	want := dumpSizeofFixture{Payload: []byte{0x01, 0x02, 0x03}, Tail: 0x99}
+
+	buf := new(bytes.Buffer)
+	if err := NewEncoder(buf).Encode(&want); err != nil {
+		t.Fatalf("encode: %s", err)
+	}
+
+	var got dumpSizeofFixture
+	trace, err := Dump(&got, buf.Bytes())
+	if err != nil {
+		t.Fatalf("dump: %s", err)
+	}
+
+	if got.Tail != want.Tail {
+		t.Fatalf("got Tail=%#x, want %#x (sizeof-linked slice drifted the remaining fields)", got.Tail, want.Tail)
+	}
+	if !bytes.Equal(got.Payload, want.Payload) {
+		t.Fatalf("got Payload=%v, want %v", got.Payload, want.Payload)
+	}
+	if !strings.Contains(trace, "sizeof length=3") {
+		t.Fatalf("trace doesn't mention the sizeof-derived length:\n%s", trace)
+	}
+	if strings.Contains(trace, "varuint length=3") {
+		t.Fatalf("trace read Payload's length as an inline varuint instead of from Length:\n%s", trace)
+	}
+}
+
+type dumpGoldenFixture struct {
+	Name   string
+	Values []uint16
+	Active bool
+}
+
+func TestTrace_StableAcrossRuns(t *testing.T) {
+	v := dumpGoldenFixture{Name: "alpha", Values: []uint16{1, 2, 3}, Active: true}
+
+	buf := new(bytes.Buffer)
+	if err := NewEncoder(buf).Encode(&v); err != nil {
+		t.Fatalf("encode: %s", err)
+	}
+
+	var first string
+	for i := 0; i < 5; i++ {
+		var out dumpGoldenFixture
+		trace, err := Dump(&out, buf.Bytes())
+		if err != nil {
+			t.Fatalf("dump (iteration %d): %s", i, err)
+		}
+		if first == "" {
+			first = trace
+			continue
+		}
+		if trace != first {
+			t.Fatalf("trace output is not stable across runs, iteration %d:\n--- first ---\n%s\n--- got ---\n%s", i, first, trace)
+		}
+	}
+
+	for _, want := range []string{
+		`dumpGoldenFixture.Name`,
+		`"alpha"`,
+		`dumpGoldenFixture.Values`,
+		`dumpGoldenFixture.Active`,
+		`true`,
+	} {
+		if !strings.Contains(first, want) {
+			t.Fatalf("trace missing expected fragment %q:\n%s", want, first)
+		}
+	}
+}
+
+// dumpGoldenSmallFixture is small and fixed enough that its full trace
+// output can be reproduced byte-for-byte, so this asserts the exact
+// line/offset/hex/value formatting tracer.line produces - not just that a
+// few substrings appear in it. Its fields emit no tracer.marker lines.
+type dumpGoldenSmallFixture struct {
+	A uint8
+	B bool
+}
+
+func TestTrace_GoldenOutput(t *testing.T) {
+	v := dumpGoldenSmallFixture{A: 7, B: true}
+
+	buf := new(bytes.Buffer)
+	if err := NewEncoder(buf).Encode(&v); err != nil {
+		t.Fatalf("encode: %s", err)
+	}
+
+	var out dumpGoldenSmallFixture
+	trace, err := Dump(&out, buf.Bytes())
+	if err != nil {
+		t.Fatalf("dump: %s", err)
+	}
+
+	indent := "  " // walkStruct's single level of depth
+	want := indent + fmt.Sprintf("[%d:%d] %-48s %-24s %s\n", 0, 1, "dumpGoldenSmallFixture.A", HexBytes([]byte{7}).String(), "7") +
+		indent + fmt.Sprintf("[%d:%d] %-48s %-24s %s\n", 1, 2, "dumpGoldenSmallFixture.B", HexBytes([]byte{1}).String(), "true")
+
+	if trace != want {
+		t.Fatalf("trace doesn't match the golden output:\n--- want ---\n%s\n--- got ---\n%s", want, trace)
+	}
+}
@@ -0,0 +1,455 @@
+package bin
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"sort"
+
+	"go.uber.org/zap"
+)
+
+// MarshalerBinary is the interface implemented by types that can marshal
+// themselves into the EOSIO binary description understood by Decoder.
+//
+// **Warning** This is experimental, exposed only for internal usage for now.
+type MarshalerBinary interface {
+	MarshalBinary(encoder *Encoder) error
+}
+
+// Encoder implements the EOS packing, the write-side counterpart to Decoder.
+type Encoder struct {
+	writer io.Writer
+}
+
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{
+		writer: w,
+	}
+}
+
+func (e *Encoder) Encode(v interface{}) (err error) {
+	return e.encodeWithOption(v, nil)
+}
+
+func (e *Encoder) encodeWithOption(v interface{}, option *Option) (err error) {
+	marshaler, rv := encodeIndirect(v)
+	if marshaler != nil {
+		if traceEnabled {
+			zlog.Debug("using MarshalBinary method to encode type")
+		}
+		return marshaler.MarshalBinary(e)
+	}
+
+	return e.value(rv, option)
+}
+
+func (e *Encoder) value(rv reflect.Value, option *Option) (err error) {
+	if option == nil {
+		option = &Option{}
+	}
+
+	if option.isOptional() {
+		if rv.IsZero() {
+			if traceEnabled {
+				zlog.Debug("skipping optional value", typeField("type", rv))
+			}
+			return e.WriteByte(0)
+		}
+		if err = e.WriteByte(1); err != nil {
+			return err
+		}
+	}
+
+	switch rv.Kind() {
+	case reflect.String:
+		return e.WriteString(rv.String())
+	case reflect.Uint8:
+		return e.WriteByte(byte(rv.Uint()))
+	case reflect.Int8:
+		return e.WriteInt8(int8(rv.Int()))
+	case reflect.Int16:
+		return e.WriteInt16(int16(rv.Int()))
+	case reflect.Int32:
+		return e.WriteInt32(int32(rv.Int()))
+	case reflect.Int64:
+		return e.WriteInt64(rv.Int())
+	case reflect.Uint16:
+		return e.WriteUint16(uint16(rv.Uint()))
+	case reflect.Uint32:
+		return e.WriteUint32(uint32(rv.Uint()))
+	case reflect.Uint64:
+		return e.WriteUint64(rv.Uint())
+	case reflect.Float32:
+		return e.WriteFloat32(float32(rv.Float()))
+	case reflect.Float64:
+		return e.WriteFloat64(rv.Float())
+	case reflect.Bool:
+		return e.WriteBool(rv.Bool())
+	}
+
+	switch rv.Kind() {
+	case reflect.Array:
+		l := rv.Len()
+		for i := 0; i < l; i++ {
+			if err = e.encodeWithOption(rv.Index(i).Addr().Interface(), nil); err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.Slice:
+		l := rv.Len()
+		if !option.hasSizeOfSlice() {
+			if err = e.WriteUvarint64(uint64(l)); err != nil {
+				return err
+			}
+		}
+		for i := 0; i < l; i++ {
+			if err = e.encodeWithOption(rv.Index(i).Addr().Interface(), nil); err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.Map:
+		keys := rv.MapKeys()
+		sortMapKeys(keys)
+
+		if err = e.WriteUvarint64(uint64(len(keys))); err != nil {
+			return err
+		}
+
+		for _, key := range keys {
+			if err = e.encodeWithOption(addressable(key).Interface(), nil); err != nil {
+				return err
+			}
+			if err = e.encodeWithOption(addressable(rv.MapIndex(key)).Interface(), nil); err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.Struct:
+		return e.encodeStruct(rv.Type(), rv)
+	default:
+		return fmt.Errorf("encode: unsupported type %q", rv.Type())
+	}
+}
+
+// rv is the instance of the structure
+// rt is the type of the structure
+func (e *Encoder) encodeStruct(rt reflect.Type, rv reflect.Value) (err error) {
+	l := rv.NumField()
+
+	sizeOfMap := map[string]int{}
+	lastNonZeroExtension := -1
+	for i := 0; i < l; i++ {
+		fieldTag := parseFieldTag(rt.Field(i).Tag)
+		if fieldTag.Sizeof != "" {
+			sizeOfMap[fieldTag.Sizeof] = rv.FieldByName(fieldTag.Sizeof).Len()
+		}
+		if fieldTag.BinaryExtension && !rv.Field(i).IsZero() {
+			lastNonZeroExtension = i
+		}
+	}
+
+	seenBinaryExtensionField := false
+	for i := 0; i < l; i++ {
+		structField := rt.Field(i)
+
+		fieldTag := parseFieldTag(structField.Tag)
+		if fieldTag.Skip {
+			continue
+		}
+
+		if !fieldTag.BinaryExtension && seenBinaryExtensionField {
+			panic(fmt.Sprintf("the `bin:\"binary_extension\"` tags must be packed together at the end of struct fields, problematic field %q", structField.Name))
+		}
+
+		if v := rv.Field(i); v.CanSet() && structField.Name != "_" {
+			if fieldTag.BinaryExtension {
+				seenBinaryExtensionField = true
+				// Only the trailing run of zero-valued `binary_extension`
+				// fields is omitted, mirroring Decoder stopping once it
+				// runs out of bytes; a zero field followed by a later
+				// non-zero one must still be written to keep later fields
+				// positionally in sync.
+				if i > lastNonZeroExtension {
+					continue
+				}
+			}
+
+			if fieldTag.Sizeof != "" {
+				size := reflect.New(structField.Type).Elem()
+				setSizeof(size, sizeOfMap[fieldTag.Sizeof])
+				if traceEnabled {
+					zlog.Debug("writing size of field",
+						zap.String("field_name", fieldTag.Sizeof),
+						zap.Int("size", sizeOfMap[fieldTag.Sizeof]),
+					)
+				}
+				if err = e.encodeWithOption(size.Addr().Interface(), nil); err != nil {
+					return err
+				}
+				continue
+			}
+
+			if variantName, ok := variantTagName(structField.Tag); ok {
+				variant, ok := v.Interface().(*BaseVariant)
+				if !ok || variant == nil {
+					return fmt.Errorf("encode: field %q tagged bin:\"variant=%s\" is not a non-nil *BaseVariant", structField.Name, variantName)
+				}
+				if err = e.EncodeVariant(variant); err != nil {
+					return err
+				}
+				continue
+			}
+
+			option := &Option{}
+			if fieldTag.Optional {
+				option.OptionalField = true
+			}
+			if s, ok := sizeOfMap[structField.Name]; ok {
+				option.setSizeOfSlice(s)
+			}
+
+			value := v.Addr().Interface()
+
+			if traceEnabled {
+				zlog.Debug("struct field",
+					typeField(structField.Name, value),
+					zap.Reflect("field_tags", fieldTag),
+				)
+			}
+
+			if err = e.encodeWithOption(value, option); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// setSizeof is the write-side counterpart to the decoder's `sizeof`: it
+// stores n, the actual length of the slice described by a `bin:"sizeof=..."`
+// field, into rv so callers never have to keep that field in sync by hand.
+func setSizeof(rv reflect.Value, n int) {
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		rv.SetInt(int64(n))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		rv.SetUint(uint64(n))
+	default:
+		panic(fmt.Sprintf("sizeof field %s not an integer type", rv.Type()))
+	}
+}
+
+// addressable copies rv into a freshly allocated, addressable location so
+// it can be passed to encodeWithOption the same way struct and slice fields
+// are (as a pointer) - needed for map keys/values, which reflect.Value
+// never hands back as addressable.
+func addressable(rv reflect.Value) reflect.Value {
+	addr := reflect.New(rv.Type())
+	addr.Elem().Set(rv)
+	return addr
+}
+
+// sortMapKeys orders keys so that encoding the same map always produces the
+// same bytes - a requirement for anything covered by a signature. String
+// keys sort lexicographically, integer keys numerically, matching what
+// EOSIO/serde-style formats expect.
+func sortMapKeys(keys []reflect.Value) {
+	if len(keys) == 0 {
+		return
+	}
+
+	switch keys[0].Kind() {
+	case reflect.String:
+		sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		sort.Slice(keys, func(i, j int) bool { return keys[i].Int() < keys[j].Int() })
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		sort.Slice(keys, func(i, j int) bool { return keys[i].Uint() < keys[j].Uint() })
+	default:
+		panic(fmt.Sprintf("bin: map key type %s has no defined deterministic sort order", keys[0].Type()))
+	}
+}
+
+// encodeIndirect mirrors `indirect` on the decode side: it walks down v's
+// pointers looking for a MarshalerBinary to hand off to, and otherwise
+// returns the concrete, dereferenced reflect.Value to encode.
+func encodeIndirect(v interface{}) (MarshalerBinary, reflect.Value) {
+	if m, ok := v.(MarshalerBinary); ok {
+		return m, reflect.Value{}
+	}
+
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil, rv
+		}
+		if m, ok := rv.Interface().(MarshalerBinary); ok {
+			return m, reflect.Value{}
+		}
+		rv = rv.Elem()
+	}
+	return nil, rv
+}
+
+func (e *Encoder) WriteByteArray(data []byte) (err error) {
+	if err = e.WriteUvarint64(uint64(len(data))); err != nil {
+		return err
+	}
+
+	_, err = e.writer.Write(data)
+	if traceEnabled {
+		zlog.Debug("wrote byte array", zap.Stringer("hex", HexBytes(data)))
+	}
+	return
+}
+
+func (e *Encoder) WriteByte(b byte) (err error) {
+	_, err = e.writer.Write([]byte{b})
+	if traceEnabled {
+		zlog.Debug("wrote byte", zap.Uint8("byte", b))
+	}
+	return
+}
+
+func (e *Encoder) WriteBool(b bool) (err error) {
+	if traceEnabled {
+		zlog.Debug("write bool", zap.Bool("val", b))
+	}
+	if b {
+		return e.WriteByte(1)
+	}
+	return e.WriteByte(0)
+}
+
+func (e *Encoder) WriteUint8(i uint8) (err error) {
+	return e.WriteByte(i)
+}
+
+func (e *Encoder) WriteInt8(i int8) (err error) {
+	return e.WriteByte(byte(i))
+}
+
+func (e *Encoder) WriteUint16(i uint16) (err error) {
+	var buf [2]byte
+	binary.LittleEndian.PutUint16(buf[:], i)
+
+	_, err = e.writer.Write(buf[:])
+	if traceEnabled {
+		zlog.Debug("wrote uint16", zap.Uint16("val", i))
+	}
+	return
+}
+
+func (e *Encoder) WriteInt16(i int16) (err error) {
+	return e.WriteUint16(uint16(i))
+}
+
+func (e *Encoder) WriteUint32(i uint32) (err error) {
+	var buf [4]byte
+	binary.LittleEndian.PutUint32(buf[:], i)
+
+	_, err = e.writer.Write(buf[:])
+	if traceEnabled {
+		zlog.Debug("wrote uint32", zap.Uint32("val", i))
+	}
+	return
+}
+
+func (e *Encoder) WriteInt32(i int32) (err error) {
+	return e.WriteUint32(uint32(i))
+}
+
+func (e *Encoder) WriteUint64(i uint64) (err error) {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], i)
+
+	_, err = e.writer.Write(buf[:])
+	if traceEnabled {
+		zlog.Debug("wrote uint64", zap.Uint64("val", i), zap.Stringer("hex", HexBytes(buf[:])))
+	}
+	return
+}
+
+func (e *Encoder) WriteInt64(i int64) (err error) {
+	return e.WriteUint64(uint64(i))
+}
+
+func (e *Encoder) WriteUint128(i Uint128) (err error) {
+	var buf [16]byte
+	binary.LittleEndian.PutUint64(buf[:8], i.Lo)
+	binary.LittleEndian.PutUint64(buf[8:], i.Hi)
+
+	_, err = e.writer.Write(buf[:])
+	if traceEnabled {
+		zlog.Debug("wrote uint128", zap.Stringer("hex", i), zap.Uint64("hi", i.Hi), zap.Uint64("lo", i.Lo))
+	}
+	return
+}
+
+func (e *Encoder) WriteInt128(i Int128) (err error) {
+	return e.WriteUint128(Uint128(i))
+}
+
+func (e *Encoder) WriteFloat32(f float32) (err error) {
+	return e.WriteUint32(math.Float32bits(f))
+}
+
+func (e *Encoder) WriteFloat64(f float64) (err error) {
+	return e.WriteUint64(math.Float64bits(f))
+}
+
+func (e *Encoder) WriteFloat128(f Float128) (err error) {
+	return e.WriteUint128(Uint128(f))
+}
+
+func (e *Encoder) WriteString(s string) (err error) {
+	return e.WriteByteArray([]byte(s))
+}
+
+func (e *Encoder) WriteUvarint64(i uint64) (err error) {
+	var buf [maxVarintBytes]byte
+	n := 0
+	x := i
+	for x >= 0x80 {
+		buf[n] = byte(x) | 0x80
+		x >>= 7
+		n++
+	}
+	buf[n] = byte(x)
+	n++
+
+	_, err = e.writer.Write(buf[:n])
+	if traceEnabled {
+		zlog.Debug("wrote uvarint64", zap.Uint64("val", i))
+	}
+	return
+}
+
+func (e *Encoder) WriteVarint64(i int64) (err error) {
+	ux := uint64(i) << 1
+	if i < 0 {
+		ux = ^ux
+	}
+	return e.WriteUvarint64(ux)
+}
+
+func (e *Encoder) WriteUvarint32(i uint32) (err error) {
+	return e.WriteUvarint64(uint64(i))
+}
+
+func (e *Encoder) WriteVarint32(i int32) (err error) {
+	return e.WriteVarint64(int64(i))
+}
+
+func (e *Encoder) WriteUvarint16(i uint16) (err error) {
+	return e.WriteUvarint64(uint64(i))
+}
+
+func (e *Encoder) WriteVarint16(i int16) (err error) {
+	return e.WriteVarint64(int64(i))
+}
@@ -0,0 +1,133 @@
+package bin
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+type roundTripPrimitives struct {
+	Name    string
+	Count   uint32
+	Balance int64
+	Flag    bool
+	Ratio   float64
+}
+
+type roundTripSizeof struct {
+	Count uint16 `bin:"sizeof=Items"`
+	Items []uint32
+}
+
+type roundTripOptional struct {
+	Present  bool
+	Nickname string `bin:"optional"`
+}
+
+type roundTripExtension struct {
+	Base uint32
+	A    uint32 `bin:"binary_extension"`
+	B    uint32 `bin:"binary_extension"`
+}
+
+func roundTrip(t *testing.T, v interface{}, out interface{}) []byte {
+	t.Helper()
+
+	buf := new(bytes.Buffer)
+	if err := NewEncoder(buf).Encode(v); err != nil {
+		t.Fatalf("encode: %s", err)
+	}
+	if err := NewDecoder(buf.Bytes()).Decode(out); err != nil {
+		t.Fatalf("decode: %s", err)
+	}
+	return buf.Bytes()
+}
+
+func TestEncoder_RoundTripPrimitives(t *testing.T) {
+	want := roundTripPrimitives{Name: "eosio", Count: 42, Balance: -1234, Flag: true, Ratio: 3.5}
+
+	var got roundTripPrimitives
+	roundTrip(t, &want, &got)
+
+	if !reflect.DeepEqual(want, got) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestEncoder_RoundTripSizeofSlice(t *testing.T) {
+	want := roundTripSizeof{Items: []uint32{10, 20, 30}}
+
+	var got roundTripSizeof
+	data := roundTrip(t, &want, &got)
+
+	// sizeof-linked slices carry no inline varuint prefix: Count (2 bytes)
+	// + 3 * uint32 (4 bytes) = 14 bytes, nothing more.
+	if len(data) != 14 {
+		t.Fatalf("got %d encoded bytes, want 14 (no length prefix on Items)", len(data))
+	}
+	if got.Count != 3 {
+		t.Fatalf("got Count=%d, want 3 (computed from len(Items), not copied from input)", got.Count)
+	}
+	if !reflect.DeepEqual(want.Items, got.Items) {
+		t.Fatalf("got Items=%v, want %v", got.Items, want.Items)
+	}
+}
+
+func TestEncoder_RoundTripOptionalPresent(t *testing.T) {
+	want := roundTripOptional{Present: true, Nickname: "k"}
+
+	var got roundTripOptional
+	roundTrip(t, &want, &got)
+
+	if !reflect.DeepEqual(want, got) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestEncoder_RoundTripOptionalAbsent(t *testing.T) {
+	want := roundTripOptional{Present: false, Nickname: ""}
+
+	var got roundTripOptional
+	roundTrip(t, &want, &got)
+
+	if got.Nickname != "" {
+		t.Fatalf("got Nickname=%q, want empty (optional not present)", got.Nickname)
+	}
+}
+
+// TestEncoder_BinaryExtensionOnlyOmitsTrailingZeroRun guards against
+// collapsing the first zero-valued binary_extension field into "stop
+// writing": a zero field followed by a later non-zero one must still be
+// written, only a genuine trailing run of zeros is dropped.
+func TestEncoder_BinaryExtensionOnlyOmitsTrailingZeroRun(t *testing.T) {
+	want := roundTripExtension{Base: 7, A: 0, B: 9}
+
+	var got roundTripExtension
+	roundTrip(t, &want, &got)
+
+	if !reflect.DeepEqual(want, got) {
+		t.Fatalf("got %+v, want %+v (A=0 followed by non-zero B must round-trip)", got, want)
+	}
+}
+
+func TestEncoder_BinaryExtensionTrailingZerosOmitted(t *testing.T) {
+	want := roundTripExtension{Base: 7, A: 0, B: 0}
+
+	data := new(bytes.Buffer)
+	if err := NewEncoder(data).Encode(&want); err != nil {
+		t.Fatalf("encode: %s", err)
+	}
+
+	// Base (4 bytes) only; both trailing zero extension fields are omitted.
+	if data.Len() != 4 {
+		t.Fatalf("got %d encoded bytes, want 4 (trailing zero extensions omitted)", data.Len())
+	}
+
+	var got roundTripExtension
+	if err := NewDecoder(data.Bytes()).Decode(&got); err != nil {
+		t.Fatalf("decode: %s", err)
+	}
+	if !reflect.DeepEqual(want, got) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,146 @@
+package bin
+
+import (
+	"encoding/binary"
+	"fmt"
+	"reflect"
+)
+
+// fastpath decodes v directly against its concrete Go type, bypassing the
+// reflect.Value.SetXxx path in `value`. Profiles of payloads with lots of
+// uint32/uint64/[]byte fields (EOSIO actions, in particular) spend most of
+// their time in reflection, so - borrowing the approach ugorji's codec uses
+// for its own fastpath - we special-case the types most commonly seen in
+// struct fields before ever constructing a reflect.Value for them.
+//
+// It returns ok=false for any type it doesn't recognize, in which case the
+// caller falls through to the regular reflection-based decode.
+//
+// option is consulted for the slice-shaped cases: a `bin:"sizeof=..."`
+// slice takes its length from a preceding size field rather than an inline
+// varuint prefix, which the fastpath's own readers don't know how to do, so
+// those cases bail out to let the reflection path (which does) handle it.
+func (d *Decoder) fastpath(v interface{}, option *Option) (ok bool, err error) {
+	switch tv := v.(type) {
+	case *bool:
+		*tv, err = d.ReadBool()
+	case *int8:
+		*tv, err = d.ReadInt8()
+	case *int16:
+		*tv, err = d.ReadInt16()
+	case *int32:
+		*tv, err = d.ReadInt32()
+	case *int64:
+		*tv, err = d.ReadInt64()
+	case *uint8:
+		*tv, err = d.ReadByte()
+	case *uint16:
+		*tv, err = d.ReadUint16()
+	case *uint32:
+		*tv, err = d.ReadUint32()
+	case *uint64:
+		*tv, err = d.ReadUint64()
+	case *float32:
+		*tv, err = d.ReadFloat32()
+	case *float64:
+		*tv, err = d.ReadFloat64()
+	case *string:
+		*tv, err = d.ReadString()
+	case *[]byte:
+		if option != nil && option.hasSizeOfSlice() {
+			return false, nil
+		}
+		var data []byte
+		data, err = d.ReadByteArray()
+		if err == nil {
+			// ReadByteArray aliases the decoder's own buffer; copy it so
+			// the caller can keep or mutate the result without corrupting
+			// (or being corrupted by) the source.
+			out := make([]byte, len(data))
+			copy(out, data)
+			*tv = out
+		}
+	case *Uint128:
+		*tv, err = d.ReadUint128("uint128")
+	case *Int128:
+		*tv, err = d.ReadInt128()
+	case *Float128:
+		*tv, err = d.ReadFloat128()
+	case *[]uint32:
+		if option != nil && option.hasSizeOfSlice() {
+			return false, nil
+		}
+		*tv, err = d.readUint32Slice()
+	case *[]uint64:
+		if option != nil && option.hasSizeOfSlice() {
+			return false, nil
+		}
+		*tv, err = d.readUint64Slice()
+	default:
+		return false, nil
+	}
+	return true, err
+}
+
+// fastpathByteArray handles `[N]byte`-shaped fields (N can't be matched by
+// a type switch since it's part of the type), reading the whole array in a
+// single call instead of looping N times through `value`.
+func (d *Decoder) fastpathByteArray(rv reflect.Value) (ok bool, err error) {
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return false, nil
+	}
+
+	elem := rv.Elem()
+	if elem.Kind() != reflect.Array || elem.Type().Elem().Kind() != reflect.Uint8 {
+		return false, nil
+	}
+
+	out := elem.Slice(0, elem.Len()).Interface().([]byte)
+	if err := d.reader.readb(out); err != nil {
+		return true, fmt.Errorf("[%d]byte: %s", elem.Len(), err)
+	}
+	return true, nil
+}
+
+// readUintSlice reads a varuint64 length prefix followed by `length`
+// fixed-size elements, returning the raw element bytes in one shot so
+// callers can decode them with a tight loop instead of one `value` call per
+// element.
+func (d *Decoder) readUintSlice(elemSize int) (data []byte, length int, err error) {
+	l, err := d.ReadUvarint64()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	data, err = d.reader.readn(int(l) * elemSize)
+	if err != nil {
+		return nil, 0, fmt.Errorf("slice: varlen=%d, %s", l, err)
+	}
+	return data, int(l), nil
+}
+
+func (d *Decoder) readUint32Slice() (out []uint32, err error) {
+	data, l, err := d.readUintSlice(TypeSize.Uint32)
+	if err != nil {
+		return nil, err
+	}
+
+	out = make([]uint32, l)
+	for i := range out {
+		out[i] = binary.LittleEndian.Uint32(data[i*TypeSize.Uint32:])
+	}
+	return out, nil
+}
+
+func (d *Decoder) readUint64Slice() (out []uint64, err error) {
+	data, l, err := d.readUintSlice(TypeSize.Uint64)
+	if err != nil {
+		return nil, err
+	}
+
+	out = make([]uint64, l)
+	for i := range out {
+		out[i] = binary.LittleEndian.Uint64(data[i*TypeSize.Uint64:])
+	}
+	return out, nil
+}
@@ -0,0 +1,183 @@
+package bin
+
+import (
+	"bytes"
+	"encoding/binary"
+	"reflect"
+	"testing"
+)
+
+// sizeofUint32Slice exercises a `bin:"sizeof=..."` slice whose element type
+// (uint32) also has a fastpath reader, to guard against the fastpath
+// swallowing a length prefix that was never written because the real
+// length lives in Count.
+type sizeofUint32Slice struct {
+	Count uint32 `bin:"sizeof=Data"`
+	Data  []uint32
+	Tail  uint8
+}
+
+func TestFastpath_SizeofSliceSkipsFastpathPrefix(t *testing.T) {
+	var buf bytes.Buffer
+	writeUint32LE(&buf, 3)
+	writeUint32LE(&buf, 10)
+	writeUint32LE(&buf, 20)
+	writeUint32LE(&buf, 30)
+	buf.WriteByte(0x42)
+
+	var out sizeofUint32Slice
+	if err := NewDecoder(buf.Bytes()).Decode(&out); err != nil {
+		t.Fatalf("decode: %s", err)
+	}
+
+	if !reflect.DeepEqual(out.Data, []uint32{10, 20, 30}) {
+		t.Fatalf("got Data=%v, want [10 20 30]", out.Data)
+	}
+	if out.Tail != 0x42 {
+		t.Fatalf("got Tail=%#x, want 0x42 (stream desynced)", out.Tail)
+	}
+}
+
+type sizeofByteSlice struct {
+	Count uint32 `bin:"sizeof=Data"`
+	Data  []byte
+	Tail  uint8
+}
+
+func TestFastpath_SizeofByteSliceSkipsFastpathPrefix(t *testing.T) {
+	var buf bytes.Buffer
+	writeUint32LE(&buf, 2)
+	buf.Write([]byte{0xAA, 0xBB})
+	buf.WriteByte(0x42)
+
+	var out sizeofByteSlice
+	if err := NewDecoder(buf.Bytes()).Decode(&out); err != nil {
+		t.Fatalf("decode: %s", err)
+	}
+
+	if !bytes.Equal(out.Data, []byte{0xAA, 0xBB}) {
+		t.Fatalf("got Data=%x, want aabb", out.Data)
+	}
+	if out.Tail != 0x42 {
+		t.Fatalf("got Tail=%#x, want 0x42 (stream desynced)", out.Tail)
+	}
+}
+
+func TestFastpath_ByteSliceDoesNotAliasSourceBuffer(t *testing.T) {
+	data := []byte{0x02, 0xAA, 0xBB}
+
+	var out []byte
+	if err := NewDecoder(data).Decode(&out); err != nil {
+		t.Fatalf("decode: %s", err)
+	}
+
+	data[1] = 0xFF
+	if !bytes.Equal(out, []byte{0xAA, 0xBB}) {
+		t.Fatalf("decoded slice aliases source buffer: got %x after mutating source", out)
+	}
+}
+
+// optionalByteArrayFixture exercises a fixed-size `[N]byte` array that is
+// also tagged optional, to guard against fastpathByteArray running before
+// the presence byte is consumed.
+type optionalByteArrayFixture struct {
+	Pre  uint8
+	Arr  [4]byte `bin:"optional"`
+	Post uint8
+}
+
+func TestFastpath_OptionalByteArrayRoundTrip(t *testing.T) {
+	present := optionalByteArrayFixture{Pre: 0x11, Arr: [4]byte{1, 2, 3, 4}, Post: 0x22}
+
+	var buf bytes.Buffer
+	if err := NewEncoder(&buf).Encode(&present); err != nil {
+		t.Fatalf("encode: %s", err)
+	}
+
+	var out optionalByteArrayFixture
+	if err := NewDecoder(buf.Bytes()).Decode(&out); err != nil {
+		t.Fatalf("decode: %s", err)
+	}
+	if !reflect.DeepEqual(out, present) {
+		t.Fatalf("got %+v, want %+v", out, present)
+	}
+
+	absent := optionalByteArrayFixture{Pre: 0x11, Post: 0x22}
+
+	buf.Reset()
+	if err := NewEncoder(&buf).Encode(&absent); err != nil {
+		t.Fatalf("encode absent: %s", err)
+	}
+
+	var gotAbsent optionalByteArrayFixture
+	if err := NewDecoder(buf.Bytes()).Decode(&gotAbsent); err != nil {
+		t.Fatalf("decode absent: %s", err)
+	}
+	if gotAbsent != absent {
+		t.Fatalf("got %+v, want %+v (Arr should stay zero when absent)", gotAbsent, absent)
+	}
+}
+
+func writeUint32LE(buf *bytes.Buffer, v uint32) {
+	var tmp [4]byte
+	binary.LittleEndian.PutUint32(tmp[:], v)
+	buf.Write(tmp[:])
+}
+
+// namedUint32/namedUint64 share the same reflect.Kind as uint32/uint64 but
+// aren't matched by fastpath's type switch, so decoding into them exercises
+// the pre-fastpath reflection-only path for comparison.
+type namedUint32 uint32
+type namedUint64 uint64
+
+type fastpathBenchStruct struct {
+	A uint32
+	B uint64
+	C uint32
+	D uint64
+}
+
+type reflectBenchStruct struct {
+	A namedUint32
+	B namedUint64
+	C namedUint32
+	D namedUint64
+}
+
+func benchPayload() []byte {
+	var buf bytes.Buffer
+	var tmp [8]byte
+	binary.LittleEndian.PutUint32(tmp[:4], 1)
+	buf.Write(tmp[:4])
+	binary.LittleEndian.PutUint64(tmp[:8], 2)
+	buf.Write(tmp[:8])
+	binary.LittleEndian.PutUint32(tmp[:4], 3)
+	buf.Write(tmp[:4])
+	binary.LittleEndian.PutUint64(tmp[:8], 4)
+	buf.Write(tmp[:8])
+	return buf.Bytes()
+}
+
+func BenchmarkDecodeFastpath(b *testing.B) {
+	data := benchPayload()
+	var out fastpathBenchStruct
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if err := NewDecoder(data).Decode(&out); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDecodeReflect(b *testing.B) {
+	data := benchPayload()
+	var out reflectBenchStruct
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if err := NewDecoder(data).Decode(&out); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
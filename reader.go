@@ -0,0 +1,110 @@
+package bin
+
+import (
+	"bufio"
+	"errors"
+	"io"
+)
+
+// decReader is the low-level pull-source a Decoder reads from. Having two
+// implementations behind this interface - one over an in-memory slice, one
+// over an io.Reader - lets the rest of the Decoder stay agnostic to whether
+// the payload was fully materialized ahead of time or is being streamed in,
+// similar to how go-codec's decReader abstracts bytes vs io.Reader decoding.
+type decReader interface {
+	// readn returns the next n bytes. Implementations are free to return a
+	// slice aliasing their own internal storage; callers that need to keep
+	// the result beyond the current call must copy it themselves.
+	readn(n int) ([]byte, error)
+	// readb fills out completely from the stream.
+	readb(out []byte) error
+	// readn1 reads a single byte.
+	readn1() (byte, error)
+	// EOF reports whether the stream has no more bytes to read.
+	EOF() bool
+}
+
+var errShortBuffer = errors.New("bin: not enough bytes to read")
+
+// bytesDecReader is a zero-copy decReader over an already materialized byte
+// slice: readn never allocates or copies, it only slices into data (readb
+// copies into the caller's own slice).
+type bytesDecReader struct {
+	data []byte
+	pos  int
+}
+
+func newBytesDecReader(data []byte) *bytesDecReader {
+	return &bytesDecReader{data: data}
+}
+
+func (r *bytesDecReader) readn(n int) ([]byte, error) {
+	if n == 0 {
+		return nil, nil
+	}
+	if r.pos+n > len(r.data) {
+		return nil, errShortBuffer
+	}
+	out := r.data[r.pos : r.pos+n]
+	r.pos += n
+	return out, nil
+}
+
+func (r *bytesDecReader) readb(out []byte) error {
+	data, err := r.readn(len(out))
+	if err != nil {
+		return err
+	}
+	copy(out, data)
+	return nil
+}
+
+func (r *bytesDecReader) readn1() (byte, error) {
+	if r.pos >= len(r.data) {
+		return 0, errShortBuffer
+	}
+	b := r.data[r.pos]
+	r.pos++
+	return b, nil
+}
+
+func (r *bytesDecReader) EOF() bool {
+	return r.pos >= len(r.data)
+}
+
+// ioDecReader is a decReader that pulls from an io.Reader through a
+// buffered reader, so a Decoder can consume arbitrarily large streams (a
+// net.Conn, a large file on disk, ...) without ever buffering the full
+// payload in memory up front.
+type ioDecReader struct {
+	r *bufio.Reader
+}
+
+func newIODecReader(r io.Reader) *ioDecReader {
+	return &ioDecReader{r: bufio.NewReader(r)}
+}
+
+func (r *ioDecReader) readn(n int) ([]byte, error) {
+	if n == 0 {
+		return nil, nil
+	}
+	out := make([]byte, n)
+	if _, err := io.ReadFull(r.r, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (r *ioDecReader) readb(out []byte) error {
+	_, err := io.ReadFull(r.r, out)
+	return err
+}
+
+func (r *ioDecReader) readn1() (byte, error) {
+	return r.r.ReadByte()
+}
+
+func (r *ioDecReader) EOF() bool {
+	_, err := r.r.Peek(1)
+	return err != nil
+}
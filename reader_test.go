@@ -0,0 +1,93 @@
+package bin
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+type streamPayload struct {
+	Magic uint32
+	Body  []byte
+	Trail uint64
+}
+
+func buildStreamPayload(t *testing.T, size int) ([]byte, streamPayload) {
+	t.Helper()
+
+	body := make([]byte, size)
+	for i := range body {
+		body[i] = byte(i)
+	}
+	want := streamPayload{Magic: 0xDEADBEEF, Body: body, Trail: 0x1122334455667788}
+
+	buf := new(bytes.Buffer)
+	if err := NewEncoder(buf).Encode(&want); err != nil {
+		t.Fatalf("encode fixture: %s", err)
+	}
+	return buf.Bytes(), want
+}
+
+func assertStreamPayloadEqual(t *testing.T, want, got streamPayload) {
+	t.Helper()
+
+	if got.Magic != want.Magic || got.Trail != want.Trail {
+		t.Fatalf("got Magic=%#x Trail=%#x, want Magic=%#x Trail=%#x", got.Magic, got.Trail, want.Magic, want.Trail)
+	}
+	if !bytes.Equal(got.Body, want.Body) {
+		t.Fatalf("decoded body does not match (len got=%d want=%d)", len(got.Body), len(want.Body))
+	}
+}
+
+func TestDecoder_StreamingFromBytesBuffer(t *testing.T) {
+	data, want := buildStreamPayload(t, 2*1024*1024)
+
+	var got streamPayload
+	if err := NewDecoderFromReader(bytes.NewBuffer(data)).Decode(&got); err != nil {
+		t.Fatalf("decode: %s", err)
+	}
+	assertStreamPayloadEqual(t, want, got)
+}
+
+// chunkedConn mimics a net.Conn's Read semantics: each call hands back at
+// most a small, fixed number of bytes - never the whole payload - so a
+// decoder reading through it is forced to pull incrementally instead of
+// being handed everything in one shot.
+type chunkedConn struct {
+	r         io.Reader
+	chunkSize int
+}
+
+func (c *chunkedConn) Read(p []byte) (int, error) {
+	if len(p) > c.chunkSize {
+		p = p[:c.chunkSize]
+	}
+	return c.r.Read(p)
+}
+
+func TestDecoder_StreamingFromNetConnShapedReader(t *testing.T) {
+	data, want := buildStreamPayload(t, 3*1024*1024)
+
+	conn := &chunkedConn{r: bytes.NewReader(data), chunkSize: 512}
+
+	var got streamPayload
+	if err := NewDecoderFromReader(conn).Decode(&got); err != nil {
+		t.Fatalf("decode: %s", err)
+	}
+	assertStreamPayloadEqual(t, want, got)
+}
+
+func TestIODecReader_EOF(t *testing.T) {
+	r := newIODecReader(bytes.NewReader([]byte{0x01}))
+	if r.EOF() {
+		t.Fatalf("reader reported EOF before its single byte was consumed")
+	}
+
+	if _, err := r.readn1(); err != nil {
+		t.Fatalf("readn1: %s", err)
+	}
+
+	if !r.EOF() {
+		t.Fatalf("reader did not report EOF after consuming its only byte")
+	}
+}
@@ -0,0 +1,137 @@
+package bin
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// VariantType pairs a variant's registered name with a zero-value instance
+// of the Go type its payload decodes into; only the instance's reflect.Type
+// is used.
+type VariantType struct {
+	Name string
+	Type interface{}
+}
+
+// VariantDefinition is the read-only registry backing BaseVariant decoding:
+// it maps the wire's type id (its position in the declaration order) to the
+// variant's name and Go type, the same scheme EOSIO's ABI uses for its
+// `variant` construct (a varuint32 discriminator followed by one of N
+// payload types).
+type VariantDefinition struct {
+	idToName map[uint32]string
+	idToType map[uint32]reflect.Type
+	nameToID map[string]uint32
+}
+
+// NewVariantDefinition builds a VariantDefinition from variants, in the
+// exact order their type id should be assigned.
+func NewVariantDefinition(variants []VariantType) *VariantDefinition {
+	def := &VariantDefinition{
+		idToName: make(map[uint32]string, len(variants)),
+		idToType: make(map[uint32]reflect.Type, len(variants)),
+		nameToID: make(map[string]uint32, len(variants)),
+	}
+
+	for i, v := range variants {
+		id := uint32(i)
+		def.idToName[id] = v.Name
+		def.idToType[id] = reflect.TypeOf(v.Type)
+		def.nameToID[v.Name] = id
+	}
+	return def
+}
+
+func (d *VariantDefinition) Name(typeID uint32) (string, bool) {
+	name, ok := d.idToName[typeID]
+	return name, ok
+}
+
+func (d *VariantDefinition) TypeID(name string) (uint32, bool) {
+	id, ok := d.nameToID[name]
+	return id, ok
+}
+
+func (d *VariantDefinition) typeGo(typeID uint32) (reflect.Type, bool) {
+	t, ok := d.idToType[typeID]
+	return t, ok
+}
+
+// BaseVariant is the decoded form of an EOSIO `variant`: TypeID is the
+// discriminator read off the wire, Impl is the concrete, freshly allocated
+// payload it pointed to - a `*transaction_trace_v0`, for example.
+//
+// TypeID is deliberately a uint32 rather than a uint8: the wire tag is read
+// as a varuint32 (see DecodeVariant), and narrowing it to a byte would
+// silently truncate any VariantDefinition with 128 or more registered types.
+type BaseVariant struct {
+	TypeID uint32
+	Impl   interface{}
+}
+
+// DecodeVariant reads a varuint32 type id, resolves it against def, and
+// recursively decodes the payload into a freshly allocated instance of the
+// matching Go type.
+func (d *Decoder) DecodeVariant(def *VariantDefinition) (out *BaseVariant, err error) {
+	typeID, err := d.ReadUvarint32()
+	if err != nil {
+		return nil, fmt.Errorf("decode variant: read type id: %s", err)
+	}
+
+	typeGo, known := def.typeGo(typeID)
+	if !known {
+		return nil, fmt.Errorf("decode variant: unknown type id %d", typeID)
+	}
+
+	value := reflect.New(typeGo)
+	if err = d.decodeWithOption(value.Interface(), nil); err != nil {
+		name, _ := def.Name(typeID)
+		return nil, fmt.Errorf("decode variant: reading variant %q: %s", name, err)
+	}
+
+	return &BaseVariant{
+		TypeID: typeID,
+		Impl:   value.Interface(),
+	}, nil
+}
+
+// EncodeVariant writes variant.TypeID as a varuint32 discriminator followed
+// by its Impl payload, the write-side counterpart to DecodeVariant.
+func (e *Encoder) EncodeVariant(variant *BaseVariant) (err error) {
+	if err = e.WriteUvarint32(variant.TypeID); err != nil {
+		return fmt.Errorf("encode variant: write type id: %s", err)
+	}
+
+	if err = e.encodeWithOption(variant.Impl, nil); err != nil {
+		return fmt.Errorf("encode variant: writing impl: %s", err)
+	}
+	return nil
+}
+
+// variantRegistry resolves the definition named by a `bin:"variant=..."`
+// struct tag to the VariantDefinition it should be decoded against.
+var variantRegistry = map[string]*VariantDefinition{}
+
+// RegisterVariantDefinition makes def available to any `*BaseVariant`
+// struct field tagged `bin:"variant=name"`.
+func RegisterVariantDefinition(name string, def *VariantDefinition) {
+	variantRegistry[name] = def
+}
+
+// variantTagName extracts the `variant=name` option out of a field's `bin`
+// struct tag, independent of the rest of the tag's parsing, so auto-dispatch
+// doesn't require threading a new option through FieldTag.
+func variantTagName(tag reflect.StructTag) (name string, ok bool) {
+	raw, present := tag.Lookup("bin")
+	if !present {
+		return "", false
+	}
+
+	for _, part := range strings.Split(raw, ",") {
+		if strings.HasPrefix(part, "variant=") {
+			return strings.TrimPrefix(part, "variant="), true
+		}
+	}
+	return "", false
+}
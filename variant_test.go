@@ -0,0 +1,131 @@
+package bin
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+type transferVariant struct {
+	From string
+	To   string
+}
+
+type voteVariant struct {
+	Candidate string
+}
+
+func newActionVariantDefinition() *VariantDefinition {
+	return NewVariantDefinition([]VariantType{
+		{Name: "transfer", Type: transferVariant{}},
+		{Name: "vote", Type: voteVariant{}},
+	})
+}
+
+func TestDecodeVariant_MultiType(t *testing.T) {
+	def := newActionVariantDefinition()
+
+	buf := new(bytes.Buffer)
+	e := NewEncoder(buf)
+	if err := e.WriteUvarint32(0); err != nil { // "transfer" type id
+		t.Fatalf("write type id: %s", err)
+	}
+	if err := e.Encode(&transferVariant{From: "alice", To: "bob"}); err != nil {
+		t.Fatalf("encode transfer: %s", err)
+	}
+
+	variant, err := NewDecoder(buf.Bytes()).DecodeVariant(def)
+	if err != nil {
+		t.Fatalf("decode variant: %s", err)
+	}
+
+	if variant.TypeID != 0 {
+		t.Fatalf("got TypeID=%d, want 0", variant.TypeID)
+	}
+	got, ok := variant.Impl.(*transferVariant)
+	if !ok {
+		t.Fatalf("got Impl of type %T, want *transferVariant", variant.Impl)
+	}
+	want := &transferVariant{From: "alice", To: "bob"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestDecodeVariant_SecondType(t *testing.T) {
+	def := newActionVariantDefinition()
+
+	buf := new(bytes.Buffer)
+	e := NewEncoder(buf)
+	if err := e.WriteUvarint32(1); err != nil { // "vote" type id
+		t.Fatalf("write type id: %s", err)
+	}
+	if err := e.Encode(&voteVariant{Candidate: "carol"}); err != nil {
+		t.Fatalf("encode vote: %s", err)
+	}
+
+	variant, err := NewDecoder(buf.Bytes()).DecodeVariant(def)
+	if err != nil {
+		t.Fatalf("decode variant: %s", err)
+	}
+
+	got, ok := variant.Impl.(*voteVariant)
+	if !ok {
+		t.Fatalf("got Impl of type %T, want *voteVariant", variant.Impl)
+	}
+	if got.Candidate != "carol" {
+		t.Fatalf("got Candidate=%q, want carol", got.Candidate)
+	}
+}
+
+func TestDecodeVariant_UnknownTypeID(t *testing.T) {
+	def := newActionVariantDefinition()
+
+	buf := new(bytes.Buffer)
+	if err := NewEncoder(buf).WriteUvarint32(42); err != nil {
+		t.Fatalf("write type id: %s", err)
+	}
+
+	if _, err := NewDecoder(buf.Bytes()).DecodeVariant(def); err == nil {
+		t.Fatalf("expected an error decoding an unregistered variant type id")
+	}
+}
+
+func TestVariant_EncodeDecodeRoundTrip(t *testing.T) {
+	def := newActionVariantDefinition()
+	RegisterVariantDefinition("test_action_variant_round_trip", def)
+
+	type action struct {
+		Name    string
+		Payload *BaseVariant `bin:"variant=test_action_variant_round_trip"`
+	}
+
+	want := action{
+		Name: "do-it",
+		Payload: &BaseVariant{
+			TypeID: 1,
+			Impl:   &voteVariant{Candidate: "dave"},
+		},
+	}
+
+	buf := new(bytes.Buffer)
+	if err := NewEncoder(buf).Encode(&want); err != nil {
+		t.Fatalf("encode: %s", err)
+	}
+
+	var got action
+	if err := NewDecoder(buf.Bytes()).Decode(&got); err != nil {
+		t.Fatalf("decode: %s", err)
+	}
+
+	if got.Name != want.Name || got.Payload.TypeID != want.Payload.TypeID {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+	gotImpl, ok := got.Payload.Impl.(*voteVariant)
+	if !ok {
+		t.Fatalf("got Impl of type %T, want *voteVariant", got.Payload.Impl)
+	}
+	if *gotImpl != *want.Payload.Impl.(*voteVariant) {
+		t.Fatalf("got %+v, want %+v", gotImpl, want.Payload.Impl)
+	}
+}